@@ -0,0 +1,87 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	"github.com/spf13/cobra"
+)
+
+// NewInternalCommand returns the hidden `internal` command group, parent of subcommands that
+// `etcdbrctl` re-execs itself into rather than something an operator would run directly.
+func NewInternalCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:    "internal",
+		Hidden: true,
+		Short:  "internal: commands etcdbrctl re-execs itself into; not part of the public CLI",
+	}
+	cmd.AddCommand(newGCWorkerCommand())
+	return cmd
+}
+
+// newGCWorkerCommand returns the `internal gc-worker` command. The parent GarbageCollector
+// process spawns it to carry out a precomputed deletion plan at reduced OS scheduling priority,
+// so a large GC pass does not compete for I/O/CPU with the etcd process being backed up. It reads
+// a snapshotter.GCWorkerRequest as JSON from stdin and writes one snapshotter.GCWorkerProgress
+// line of JSON to stdout per snapshot processed.
+func newGCWorkerCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:    "gc-worker",
+		Hidden: true,
+		Short:  "internal: delete a precomputed garbage collection plan at low OS scheduling priority",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runGCWorker()
+		},
+	}
+}
+
+func runGCWorker() error {
+	lowerSelfPriority()
+
+	var req snapshotter.GCWorkerRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		return fmt.Errorf("failed to decode GC deletion plan: %v", err)
+	}
+
+	store, err := snapstore.GetSnapStore(req.StoreConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize snapstore: %v", err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	for _, deletion := range req.Deletions {
+		var delErr error
+		for i := len(deletion.Deltas) - 1; i >= 0 && delErr == nil; i-- {
+			delErr = store.Delete(*deletion.Deltas[i])
+		}
+		if delErr == nil {
+			delErr = store.Delete(*deletion.Snap)
+		}
+
+		progress := snapshotter.GCWorkerProgress{SnapName: deletion.Snap.SnapName}
+		if delErr != nil {
+			progress.Err = delErr.Error()
+		}
+		if err := encoder.Encode(progress); err != nil {
+			return fmt.Errorf("failed to report GC progress: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+// +build linux
+
+package cmd
+
+import (
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ioprioWhoProcess and ioprioClassIdle mirror <linux/ioprio.h>, which golang.org/x/sys/unix
+	// does not expose constants for.
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+// lowerSelfPriority moves the current process into the idle I/O scheduling class and the lowest
+// CPU "nice" priority, so the gc-worker child never competes with the etcd process it is backing
+// up for disk or CPU time. Both calls are best-effort: a sandboxed or unprivileged environment may
+// reject them, in which case the worker simply runs at normal priority.
+func lowerSelfPriority() {
+	pid := 0 // 0 means "the calling process" for both setpriority and ioprio_set.
+	if err := unix.Setpriority(unix.PRIO_PROCESS, pid, 19); err != nil {
+		logrus.Warnf("GC worker: failed to lower CPU scheduling priority: %v", err)
+	}
+	ioprio := (ioprioClassIdle << ioprioClassShift)
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOPRIO_SET, uintptr(ioprioWhoProcess), uintptr(pid), uintptr(ioprio)); errno != 0 {
+		logrus.Warnf("GC worker: failed to lower I/O scheduling priority: %v", errno)
+	}
+}
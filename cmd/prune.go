@@ -0,0 +1,244 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/server"
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// pruneOptions holds the flags accepted by the `snapshot prune`/`snapshot forget` command. It
+// mirrors restic's `forget`: the same --keep-* flags used to configure the KeepBuckets policy on
+// the long-running snapshotter are available here, but evaluated once against the store without
+// starting a Snapshotter at all.
+type pruneOptions struct {
+	storeConfig *snapstore.Config
+
+	keepLast    int
+	keepHourly  int
+	keepDaily   int
+	keepWeekly  int
+	keepMonthly int
+	keepYearly  int
+	keepWithin  time.Duration
+
+	groupBy string
+	tags    []string
+
+	// since, until, and kind restrict the snapshots this run considers at all, before the
+	// retention policy ever sees them; unlike --tag they don't exempt a snapshot from deletion,
+	// they remove it from the listing entirely.
+	since string
+	until string
+	kind  string
+
+	dryRun bool
+
+	// watch, when set, turns this command into the long-running periodic garbage collector
+	// instead of a one-shot evaluation: the same policy/groupBy/tags flags above configure a
+	// snapshotter.Snapshotter, whose GarbageCollector is then run until interrupted.
+	watch                          bool
+	periodSeconds                  int
+	lowPrioritySubprocessThreshold int
+	gcWorkerExecutable             string
+	// listenAddress, when --watch is set, serves GET /gc/history and GET /metrics on this
+	// address for the lifetime of the periodic garbage collector. Empty disables the listener.
+	listenAddress string
+}
+
+// NewPruneCommand returns the `snapshot prune` command (aliased `forget`), which evaluates a
+// retention policy against an existing snapstore.SnapStore and prints or executes the resulting
+// deletion plan, without requiring a running Snapshotter or GC goroutine.
+func NewPruneCommand() *cobra.Command {
+	opts := &pruneOptions{storeConfig: &snapstore.Config{}}
+
+	cmd := &cobra.Command{
+		Use:     "prune",
+		Aliases: []string{"forget"},
+		Short:   "Evaluate a retention policy against the snapstore and delete (or preview) what it rejects",
+		Long: `prune evaluates the same KeepBuckets retention policy used by the periodic garbage
+collector against the configured snapstore, and deletes the full snapshots (and their delta
+streams) it rejects. With --dry-run it only prints the decision table, so operators can safely
+preview the effect of a policy change before enabling it in production.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPrune(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.storeConfig.Provider, "storage-provider", "", "snapstore provider; only Local (the default) is supported, any other value is rejected")
+	cmd.Flags().StringVar(&opts.storeConfig.Container, "store-container", "", "container/bucket holding the snapshots; same as the backup/restore commands")
+	cmd.Flags().StringVar(&opts.storeConfig.Prefix, "store-prefix", "", "prefix under the container holding the snapshots; same as the backup/restore commands")
+	cmd.Flags().IntVar(&opts.keepLast, "keep-last", 0, "number of most recent snapshots to keep, regardless of age (0 to disable)")
+	cmd.Flags().IntVar(&opts.keepHourly, "keep-hourly", 0, "number of hourly snapshots to keep")
+	cmd.Flags().IntVar(&opts.keepDaily, "keep-daily", 0, "number of daily snapshots to keep")
+	cmd.Flags().IntVar(&opts.keepWeekly, "keep-weekly", 0, "number of weekly snapshots to keep")
+	cmd.Flags().IntVar(&opts.keepMonthly, "keep-monthly", 0, "number of monthly snapshots to keep")
+	cmd.Flags().IntVar(&opts.keepYearly, "keep-yearly", 0, "number of yearly snapshots to keep")
+	cmd.Flags().DurationVar(&opts.keepWithin, "keep-within", 0, "keep all snapshots newer than this duration, regardless of the other --keep-* limits")
+	cmd.Flags().StringVar(&opts.groupBy, "group-by", "", "partition snapshots by this Labels key before applying the policy (e.g. cluster-id)")
+	cmd.Flags().StringSliceVar(&opts.tags, "tag", nil, "only ever delete snapshots; never delete a snapshot carrying all of these tags (may be repeated)")
+	cmd.Flags().StringVar(&opts.since, "since", "", "only consider snapshots created on or after this RFC3339 timestamp")
+	cmd.Flags().StringVar(&opts.until, "until", "", "only consider snapshots created on or before this RFC3339 timestamp")
+	cmd.Flags().StringVar(&opts.kind, "kind", "", "only consider snapshots of this kind (Full or Incr)")
+	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "print the deletion plan without deleting anything")
+	cmd.Flags().BoolVar(&opts.watch, "watch", false, "run as the long-running periodic garbage collector instead of evaluating the policy once")
+	cmd.Flags().IntVar(&opts.periodSeconds, "period-seconds", 3600, "how often, in seconds, to re-evaluate the policy when --watch is set")
+	cmd.Flags().IntVar(&opts.lowPrioritySubprocessThreshold, "low-priority-subprocess-threshold", 0, "when --watch is set, hand deletions off to a low OS scheduling priority subprocess once a single run would delete at least this many snapshots (0 disables the subprocess)")
+	cmd.Flags().StringVar(&opts.gcWorkerExecutable, "gc-worker-executable", "", "when --watch is set, override the executable re-exec'd as the low-priority subprocess; defaults to this process's own executable")
+	cmd.Flags().StringVar(&opts.listenAddress, "listen-address", "", "when --watch is set, serve GET /gc/history and GET /metrics on this address (e.g. :8080); empty disables the listener")
+
+	return cmd
+}
+
+func runPrune(opts *pruneOptions) error {
+	store, err := snapstore.GetSnapStore(opts.storeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize snapstore: %v", err)
+	}
+	store, err = applyListFilter(opts, store)
+	if err != nil {
+		return err
+	}
+
+	policy := &snapshotter.KeepBucketsPolicy{
+		KeepLast:    opts.keepLast,
+		KeepHourly:  opts.keepHourly,
+		KeepDaily:   opts.keepDaily,
+		KeepWeekly:  opts.keepWeekly,
+		KeepMonthly: opts.keepMonthly,
+		KeepYearly:  opts.keepYearly,
+		KeepWithin:  opts.keepWithin,
+		DryRun:      opts.dryRun,
+	}
+	logrus.Infof("Evaluating policy: %s", policy.String())
+
+	applyOpts := snapshotter.ApplyOptions{}
+	if opts.groupBy != "" {
+		applyOpts.GroupBy = snapshotter.GroupByLabel(opts.groupBy)
+	}
+	if len(opts.tags) > 0 {
+		applyOpts.KeepTags = []snapstore.TagList{snapstore.TagList(opts.tags)}
+	}
+
+	if opts.watch {
+		return runPruneWatch(opts, store, policy, applyOpts)
+	}
+
+	report, err := snapshotter.Apply(store, policy, applyOpts, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to apply retention policy: %v", err)
+	}
+	if !opts.dryRun {
+		report = snapshotter.ExecuteDeletions(store, report)
+	}
+
+	printReport(report)
+	return nil
+}
+
+// applyListFilter wraps store in a snapstore.FilteringSnapStore if opts.since/until/kind asked for
+// one, restricting the snapshots store.List ever returns to this run, before the retention policy
+// or groupBy ever sees them.
+func applyListFilter(opts *pruneOptions, store snapstore.SnapStore) (snapstore.SnapStore, error) {
+	var filter snapstore.SnapshotFilter
+	var filtered bool
+
+	if opts.since != "" {
+		since, err := time.Parse(time.RFC3339, opts.since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse --since: %v", err)
+		}
+		filter.Since = since
+		filtered = true
+	}
+	if opts.until != "" {
+		until, err := time.Parse(time.RFC3339, opts.until)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse --until: %v", err)
+		}
+		filter.Until = until
+		filtered = true
+	}
+	if opts.kind != "" {
+		filter.Kind = snapstore.SnapshotKind(opts.kind)
+		filtered = true
+	}
+
+	if !filtered {
+		return store, nil
+	}
+	return snapstore.FilteringSnapStore{SnapStore: store, Filter: filter}, nil
+}
+
+// runPruneWatch runs the same policy opts.watch asked for as the long-running periodic garbage
+// collector, via snapshotter.Snapshotter, until interrupted.
+func runPruneWatch(opts *pruneOptions, store snapstore.SnapStore, policy *snapshotter.KeepBucketsPolicy, applyOpts snapshotter.ApplyOptions) error {
+	if opts.listenAddress != "" {
+		srv := server.NewServer(store, nil)
+		go func() {
+			logrus.Infof("Serving /gc/history and /metrics on %s", opts.listenAddress)
+			if err := http.ListenAndServe(opts.listenAddress, srv.Handler()); err != nil {
+				logrus.Warnf("GC HTTP server exited: %v", err)
+			}
+		}()
+	}
+
+	ssr := snapshotter.NewSnapshotter(snapshotter.Config{
+		Store:                          store,
+		StoreConfig:                    opts.storeConfig,
+		GarbageCollectionPeriodSeconds: time.Duration(opts.periodSeconds),
+		GarbageCollectionPolicy:        snapshotter.GarbageCollectionPolicyKeepBuckets,
+		KeepBucketsPolicy:              policy,
+		GroupBy:                        applyOpts.GroupBy,
+		KeepTags:                       applyOpts.KeepTags,
+		LowPrioritySubprocessThreshold: opts.lowPrioritySubprocessThreshold,
+		GCWorkerExecutable:             opts.gcWorkerExecutable,
+	})
+
+	stopCh := make(chan bool)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		close(stopCh)
+	}()
+
+	ssr.GarbageCollector(stopCh)
+	return nil
+}
+
+// printReport renders the decision table: snapshot name, kind, createdOn, decision, and reason.
+func printReport(report snapshotter.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tKIND\tCREATED ON\tDECISION\tREASON")
+	for _, entry := range report.Entries {
+		reason := "-"
+		if len(entry.Reasons) > 0 {
+			reason = fmt.Sprint(entry.Reasons)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Snap.SnapName, entry.Snap.Kind, entry.Snap.CreatedOn.UTC().Format(time.RFC3339), entry.Decision, reason)
+	}
+	w.Flush()
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NewSnapshotCommand returns the `snapshot` command, the parent of the commands that operate on
+// an existing snapstore's snapshots directly -- prune/forget and tag -- without starting the
+// long-running sidecar.
+func NewSnapshotCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "snapshot: evaluate retention policies and manage tags against an existing snapstore",
+	}
+	cmd.AddCommand(NewPruneCommand())
+	cmd.AddCommand(NewTagCommand())
+	return cmd
+}
+
+// NewCommand returns the root etcdbrctl command, grouping every subcommand in this package so
+// main can wire it up with a single AddCommand call.
+func NewCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "etcdbrctl",
+		Short: "etcdbrctl is the command line utility for etcd backup and restore",
+	}
+	cmd.AddCommand(NewSnapshotCommand())
+	cmd.AddCommand(NewInternalCommand())
+	return cmd
+}
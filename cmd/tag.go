@@ -0,0 +1,77 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapshot/snapshotter"
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	"github.com/spf13/cobra"
+)
+
+// tagOptions holds the flags accepted by the `snapshot tag` command.
+type tagOptions struct {
+	storeConfig *snapstore.Config
+
+	snapDir  string
+	snapName string
+	tags     []string
+}
+
+// NewTagCommand returns the `snapshot tag` command, which pins an already-taken full snapshot
+// against garbage collection by attaching one or more tags to it, matched against the --tag flag
+// of `snapshot prune`/the KeepTags policy on the long-running snapshotter.
+func NewTagCommand() *cobra.Command {
+	opts := &tagOptions{storeConfig: &snapstore.Config{}}
+
+	cmd := &cobra.Command{
+		Use:   "tag <snapshot-name>",
+		Short: "Attach tags to an already-taken snapshot so it is exempt from garbage collection",
+		Long: `tag attaches one or more tags to an already-persisted full snapshot without touching its
+body, so operators can pin a release/pre-upgrade snapshot against garbage collection after the
+fact, the same way the periodic garbage collector and "snapshot prune" exempt any snapshot
+carrying all the tags configured via --tag/KeepTags.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.snapName = args[0]
+			return runTag(opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.storeConfig.Provider, "storage-provider", "", "snapstore provider; only Local (the default) is supported, any other value is rejected")
+	cmd.Flags().StringVar(&opts.storeConfig.Container, "store-container", "", "container/bucket holding the snapshots; same as the backup/restore commands")
+	cmd.Flags().StringVar(&opts.storeConfig.Prefix, "store-prefix", "", "prefix under the container holding the snapshots; same as the backup/restore commands")
+	cmd.Flags().StringVar(&opts.snapDir, "snap-dir", "", "SnapDir of the snapshot being tagged, i.e. its directory under the store prefix (empty for a snapshot stored directly under it)")
+	cmd.Flags().StringSliceVar(&opts.tags, "tag", nil, "tag to attach to the snapshot (may be repeated)")
+	cmd.MarkFlagRequired("tag")
+
+	return cmd
+}
+
+func runTag(opts *tagOptions) error {
+	store, err := snapstore.GetSnapStore(opts.storeConfig)
+	if err != nil {
+		return fmt.Errorf("failed to initialize snapstore: %v", err)
+	}
+
+	snap := &snapstore.Snapshot{SnapDir: opts.snapDir, SnapName: opts.snapName}
+	if err := snapshotter.TagSnapshot(store, snap, opts.tags...); err != nil {
+		return fmt.Errorf("failed to tag snapshot %s: %v", opts.snapName, err)
+	}
+
+	fmt.Printf("tagged %s with %v\n", opts.snapName, opts.tags)
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"net/http"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	"github.com/sirupsen/logrus"
+)
+
+// Server serves etcdbrctl's HTTP endpoints (GET /gc/history and GET /metrics) over a single mux,
+// so every handler this package adds shares one listener.
+type Server struct {
+	mux    *http.ServeMux
+	logger *logrus.Entry
+}
+
+// NewServer returns a Server with every handler in this package registered against store, ready
+// to be served with (*Server).Handler.
+func NewServer(store snapstore.SnapStore, logger *logrus.Entry) *Server {
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	s := &Server{mux: http.NewServeMux(), logger: logger}
+	s.registerGCHistoryHandler(store)
+	s.registerMetricsHandler()
+	return s
+}
+
+// Handler returns the http.Handler serving every endpoint this package registers, for use with
+// http.ListenAndServe (or a test server).
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+)
+
+// ApplyOptions configures a single retention-policy evaluation run, independent of whether it is
+// triggered by the periodic GarbageCollector goroutine or the `etcdbrctl snapshot prune` command.
+type ApplyOptions struct {
+	GroupBy  GroupByFunc
+	KeepTags []snapstore.TagList
+}
+
+// ReportEntry records the policy's decision for a single full snapshot and, if it was rejected,
+// what it would take to remove it.
+type ReportEntry struct {
+	Snap     *snapstore.Snapshot
+	Deltas   snapstore.SnapList
+	Group    string
+	Decision string // "kept" or "deleted"
+	Reasons  []string
+	// Err is set by ExecuteDeletions if deleting Snap (or one of its deltas) failed.
+	Err error
+}
+
+// Report is the outcome of a single Apply run, one ReportEntry per full snapshot considered. The
+// always-kept latest snapStream of each group is omitted, matching the GarbageCollector goroutine's
+// long-standing behaviour of never touching the current snapstream.
+type Report struct {
+	Entries []ReportEntry
+}
+
+// Apply lists the snapshots in store and evaluates policy against the full snapshots of each
+// group (see GroupByFunc) to decide what should be deleted. It never deletes anything itself --
+// callers execute the plan via ExecuteDeletions, either in-process or, for large batches, via a
+// low-priority subprocess. Apply is the single evaluation path shared by the periodic
+// GarbageCollector goroutine and the `etcdbrctl snapshot prune` command, so previewing a policy
+// change with --dry-run and actually running it can never diverge.
+func Apply(store snapstore.SnapStore, policy RetentionPolicy, opts ApplyOptions, now time.Time) (Report, error) {
+	snapList, err := store.List()
+	if err != nil {
+		return Report{}, err
+	}
+
+	var report Report
+	streams := buildStreams(snapList)
+	groups := groupStreams(streams, opts.GroupBy)
+
+	for groupKey, groupStreams := range groups {
+		if len(groupStreams) == 0 {
+			continue
+		}
+		// The latest snapStream of the group is always kept and is not part of the report.
+		candidates := groupStreams[:len(groupStreams)-1]
+
+		var fullSnaps snapstore.SnapList
+		protected := map[string]bool{}
+		for _, s := range candidates {
+			if len(opts.KeepTags) > 0 && snapstore.MatchesAny(s.full, opts.KeepTags) {
+				protected[s.full.SnapName] = true
+				continue
+			}
+			fullSnaps = append(fullSnaps, s.full)
+		}
+
+		_, toDelete, reasons := policy.Apply(fullSnaps, now)
+		deleteSet := map[string]bool{}
+		for _, snap := range toDelete {
+			deleteSet[snap.SnapName] = true
+		}
+
+		for _, s := range candidates {
+			name := s.full.SnapName
+			if protected[name] {
+				report.Entries = append(report.Entries, ReportEntry{Snap: s.full, Group: groupKey, Decision: "kept", Reasons: []string{"tagged"}})
+				continue
+			}
+
+			entry := ReportEntry{Snap: s.full, Deltas: s.deltas, Group: groupKey, Reasons: reasons[name]}
+			if deleteSet[name] {
+				entry.Decision = "deleted"
+			} else {
+				entry.Decision = "kept"
+			}
+			report.Entries = append(report.Entries, entry)
+		}
+	}
+	return report, nil
+}
+
+// ExecuteDeletions performs the deletions described by a Report's "deleted" entries in-process,
+// newest-delta-first (working backward to the oldest) then the full snapshot, and records any
+// failure on the corresponding entry's Err field. Entries decided "kept" are left untouched.
+func ExecuteDeletions(store snapstore.SnapStore, report Report) Report {
+	for i := range report.Entries {
+		entry := &report.Entries[i]
+		if entry.Decision != "deleted" {
+			continue
+		}
+		for j := len(entry.Deltas) - 1; j >= 0 && entry.Err == nil; j-- {
+			entry.Err = store.Delete(*entry.Deltas[j])
+		}
+		if entry.Err == nil {
+			entry.Err = store.Delete(*entry.Snap)
+		}
+	}
+	return report
+}
+
+// CountDeletions returns the number of entries in report decided "deleted".
+func CountDeletions(report Report) int {
+	count := 0
+	for _, entry := range report.Entries {
+		if entry.Decision == "deleted" {
+			count++
+		}
+	}
+	return count
+}
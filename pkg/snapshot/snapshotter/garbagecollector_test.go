@@ -0,0 +1,34 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import "testing"
+
+func TestRetentionPolicyLimitBasedAccountsForImplicitLatestStream(t *testing.T) {
+	ssr := NewSnapshotter(Config{
+		GarbageCollectionPolicy: GarbageCollectionPolicyLimitBased,
+		MaxBackups:              3,
+	})
+
+	policy, ok := ssr.retentionPolicy().(*LimitBasedPolicy)
+	if !ok {
+		t.Fatalf("expected a *LimitBasedPolicy, got %T", ssr.retentionPolicy())
+	}
+	// Apply (apply.go) always keeps the latest stream itself before the policy ever runs, so the
+	// policy must only keep MaxBackups-1 more to land on a total of 3.
+	if policy.MaxBackups != 2 {
+		t.Fatalf("expected MaxBackups to be reduced by the implicit latest stream (3-1=2), got %d", policy.MaxBackups)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import "github.com/gardener/etcd-backup-restore/pkg/snapstore"
+
+// defaultGroupKey is the group a snapshot falls into when no GroupByFunc is configured, i.e. the
+// whole snap list is treated as a single group (today's behaviour).
+const defaultGroupKey = ""
+
+// GroupByFunc computes the group key for a full snapshot, e.g. the etcd cluster or member ID it
+// belongs to. Snapshots sharing a bucket/prefix but belonging to different groups are retained
+// independently, so one cluster's newer snapshots never cause another cluster's older snapshots
+// to be evicted.
+type GroupByFunc func(snap *snapstore.Snapshot) string
+
+// GroupByLabel returns a GroupByFunc that groups snapshots by the value of the given Labels key.
+// Snapshots missing the label fall back to the default group.
+func GroupByLabel(key string) GroupByFunc {
+	return func(snap *snapstore.Snapshot) string {
+		if snap.Labels == nil {
+			return defaultGroupKey
+		}
+		return snap.Labels[key]
+	}
+}
+
+// groupStream is a single full snapshot together with the delta snapshots chained onto it.
+type groupStream struct {
+	full   *snapstore.Snapshot
+	deltas snapstore.SnapList
+}
+
+// buildStreams partitions snapList (oldest-first, as returned by SnapStore.List) into the
+// individual full+delta snapStreams, preserving order.
+func buildStreams(snapList snapstore.SnapList) []groupStream {
+	var streams []groupStream
+	for _, snap := range snapList {
+		if snap.Kind == snapstore.SnapshotKindFull || len(streams) == 0 {
+			streams = append(streams, groupStream{full: snap})
+			continue
+		}
+		streams[len(streams)-1].deltas = append(streams[len(streams)-1].deltas, snap)
+	}
+	return streams
+}
+
+// groupStreams partitions streams into groups keyed by groupBy(stream.full). A nil groupBy puts
+// every stream into the single default group, preserving the original un-grouped behaviour.
+func groupStreams(streams []groupStream, groupBy GroupByFunc) map[string][]groupStream {
+	groups := map[string][]groupStream{}
+	for _, s := range streams {
+		key := defaultGroupKey
+		if groupBy != nil {
+			key = groupBy(s.full)
+		}
+		groups[key] = append(groups[key], s)
+	}
+	return groups
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"testing"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+)
+
+func TestGroupByLabel(t *testing.T) {
+	groupBy := GroupByLabel("cluster-id")
+
+	labeled := &snapstore.Snapshot{Labels: map[string]string{"cluster-id": "a"}}
+	if got := groupBy(labeled); got != "a" {
+		t.Fatalf("expected group key %q, got %q", "a", got)
+	}
+
+	unlabeled := &snapstore.Snapshot{}
+	if got := groupBy(unlabeled); got != defaultGroupKey {
+		t.Fatalf("expected a missing label to fall back to the default group, got %q", got)
+	}
+
+	nilLabels := &snapstore.Snapshot{Labels: nil}
+	if got := groupBy(nilLabels); got != defaultGroupKey {
+		t.Fatalf("expected nil Labels to fall back to the default group, got %q", got)
+	}
+}
+
+func TestBuildStreamsChainsDeltasOntoPrecedingFull(t *testing.T) {
+	full1 := &snapstore.Snapshot{Kind: snapstore.SnapshotKindFull, SnapName: "full1"}
+	delta1 := &snapstore.Snapshot{Kind: snapstore.SnapshotKindDelta, SnapName: "delta1"}
+	full2 := &snapstore.Snapshot{Kind: snapstore.SnapshotKindFull, SnapName: "full2"}
+	delta2a := &snapstore.Snapshot{Kind: snapstore.SnapshotKindDelta, SnapName: "delta2a"}
+	delta2b := &snapstore.Snapshot{Kind: snapstore.SnapshotKindDelta, SnapName: "delta2b"}
+
+	streams := buildStreams(snapstore.SnapList{full1, delta1, full2, delta2a, delta2b})
+
+	if len(streams) != 2 {
+		t.Fatalf("expected 2 streams, got %d", len(streams))
+	}
+	if streams[0].full != full1 || len(streams[0].deltas) != 1 || streams[0].deltas[0] != delta1 {
+		t.Fatalf("expected full1's stream to chain delta1, got %+v", streams[0])
+	}
+	if streams[1].full != full2 || len(streams[1].deltas) != 2 {
+		t.Fatalf("expected full2's stream to chain both deltas, got %+v", streams[1])
+	}
+}
+
+func TestBuildStreamsLeadingDeltaWithNoFull(t *testing.T) {
+	// A delta snapshot with no preceding full (e.g. the first snapshot ever taken) starts its own
+	// stream rather than being dropped.
+	delta := &snapstore.Snapshot{Kind: snapstore.SnapshotKindDelta, SnapName: "orphan-delta"}
+
+	streams := buildStreams(snapstore.SnapList{delta})
+
+	if len(streams) != 1 || streams[0].full != delta {
+		t.Fatalf("expected a single stream led by the orphan delta, got %+v", streams)
+	}
+}
+
+func TestGroupStreamsPartitionsByGroupKey(t *testing.T) {
+	streamA := groupStream{full: &snapstore.Snapshot{Labels: map[string]string{"cluster-id": "a"}}}
+	streamB := groupStream{full: &snapstore.Snapshot{Labels: map[string]string{"cluster-id": "b"}}}
+
+	groups := groupStreams([]groupStream{streamA, streamB}, GroupByLabel("cluster-id"))
+
+	if len(groups) != 2 || len(groups["a"]) != 1 || len(groups["b"]) != 1 {
+		t.Fatalf("expected one stream per group, got %+v", groups)
+	}
+}
+
+func TestGroupStreamsNilGroupByUsesSingleDefaultGroup(t *testing.T) {
+	streamA := groupStream{full: &snapstore.Snapshot{}}
+	streamB := groupStream{full: &snapstore.Snapshot{}}
+
+	groups := groupStreams([]groupStream{streamA, streamB}, nil)
+
+	if len(groups) != 1 || len(groups[defaultGroupKey]) != 2 {
+		t.Fatalf("expected both streams in the single default group, got %+v", groups)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+)
+
+// gcHistoryObjectName is the fixed object under which the GC history is persisted in the
+// snapstore, alongside the actual snapshot objects.
+const gcHistoryObjectName = "gc-history.json"
+
+// gcHistoryMaxEntries and gcHistoryMaxAge bound the persisted history so it cannot grow without
+// limit; whichever limit is hit first wins.
+const (
+	gcHistoryMaxEntries = 1000
+	gcHistoryMaxAge     = 90 * 24 * time.Hour
+)
+
+// GCHistoryEntry records what happened to a single full snapshot during one GC run, so operators
+// can answer "why was this snapshot deleted?" long after the log line that said so has rotated.
+type GCHistoryEntry struct {
+	SnapName   string    `json:"snapName"`
+	CreatedOn  time.Time `json:"createdOn"`
+	Decision   string    `json:"decision"`
+	Reason     string    `json:"reason"`
+	Err        string    `json:"err,omitempty"`
+	RecordedOn time.Time `json:"recordedOn"`
+}
+
+// GCHistory is the bounded, structured log of GC decisions persisted as a single JSON object in
+// the snapstore.
+type GCHistory struct {
+	Entries []GCHistoryEntry `json:"entries"`
+}
+
+// LoadGCHistory reads the persisted GCHistory from store. A missing object is not an error: it
+// just means no GC run has completed yet. Any other Fetch error (permission, network, backend
+// outage) is returned to the caller rather than silently treated as "no history yet", so a
+// transient failure can never result in recordGCHistory overwriting the object and discarding the
+// prior audit trail. Exposed for the HTTP server's /gc/history endpoint.
+func LoadGCHistory(store snapstore.SnapStore) (GCHistory, error) {
+	rc, err := store.Fetch(snapstore.Snapshot{SnapName: gcHistoryObjectName})
+	if err != nil {
+		if os.IsNotExist(err) {
+			return GCHistory{}, nil
+		}
+		return GCHistory{}, err
+	}
+	defer rc.Close()
+
+	data, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return GCHistory{}, err
+	}
+	var history GCHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return GCHistory{}, err
+	}
+	return history, nil
+}
+
+// recordGCHistory appends report's entries to the persisted GC history, trims it to the
+// configured bounds, and saves it back. dryRun must be true when the run only evaluated the
+// policy without deleting anything, so a "deleted" decision is recorded as "would-delete" instead
+// of misrepresenting it as an actual deletion in the audit trail.
+func recordGCHistory(store snapstore.SnapStore, report Report, now time.Time, dryRun bool) error {
+	history, err := LoadGCHistory(store)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range report.Entries {
+		reason := "-"
+		if len(entry.Reasons) > 0 {
+			reason = entry.Reasons[0]
+		}
+		errStr := ""
+		if entry.Err != nil {
+			errStr = entry.Err.Error()
+		}
+		decision := entry.Decision
+		if dryRun && decision == "deleted" {
+			decision = "would-delete"
+		}
+		history.Entries = append(history.Entries, GCHistoryEntry{
+			SnapName:   entry.Snap.SnapName,
+			CreatedOn:  entry.Snap.CreatedOn.UTC(),
+			Decision:   decision,
+			Reason:     reason,
+			Err:        errStr,
+			RecordedOn: now,
+		})
+	}
+
+	history.Entries = trimGCHistory(history.Entries, now)
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return store.Save(snapstore.Snapshot{SnapName: gcHistoryObjectName}, ioutil.NopCloser(bytes.NewReader(data)))
+}
+
+// trimGCHistory keeps, at most, the newest gcHistoryMaxEntries entries that are no older than
+// gcHistoryMaxAge, sorted oldest-first.
+func trimGCHistory(entries []GCHistoryEntry, now time.Time) []GCHistoryEntry {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].RecordedOn.Before(entries[j].RecordedOn) })
+
+	cutoff := now.Add(-gcHistoryMaxAge)
+	var kept []GCHistoryEntry
+	for _, e := range entries {
+		if e.RecordedOn.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if len(kept) > gcHistoryMaxEntries {
+		kept = kept[len(kept)-gcHistoryMaxEntries:]
+	}
+	return kept
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+)
+
+// GarbageCollectionPolicyKeepBuckets is a restic-style retention policy where the operator
+// independently configures how many snapshots to keep in each of the last/hourly/daily/weekly/
+// monthly/yearly buckets, plus an optional "keep everything within a duration" rule.
+const GarbageCollectionPolicyKeepBuckets = "KeepBuckets"
+
+// keepForever is used for a bucket limit that should never run out, mirroring restic's "-1" convention.
+const keepForever = -1
+
+// KeepBucketsPolicy holds the restic-style retention configuration for the KeepBuckets garbage
+// collection policy. Each Keep* field is the number of full snapshots to retain in that bucket,
+// or keepForever to retain all of them. A zero value disables the bucket.
+type KeepBucketsPolicy struct {
+	KeepLast    int
+	KeepHourly  int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	KeepYearly  int
+	// KeepWithin additionally retains every snapshot newer than now-KeepWithin, regardless of
+	// whether a bucket still has room for it.
+	KeepWithin time.Duration
+	// DryRun, when true, only logs the deletions that would have happened.
+	DryRun bool
+}
+
+// String renders a short human-readable summary of the policy, suitable for a startup log line.
+func (p *KeepBucketsPolicy) String() string {
+	s := fmt.Sprintf("keep %s latest, %s hourly, %s daily, %s weekly, %s monthly, %s yearly",
+		bucketSummary(p.KeepLast), bucketSummary(p.KeepHourly), bucketSummary(p.KeepDaily),
+		bucketSummary(p.KeepWeekly), bucketSummary(p.KeepMonthly), bucketSummary(p.KeepYearly))
+	if p.KeepWithin > 0 {
+		s = fmt.Sprintf("%s, and everything within %s", s, p.KeepWithin)
+	}
+	if p.DryRun {
+		s += " (dry-run)"
+	}
+	return s
+}
+
+func bucketSummary(limit int) string {
+	if limit == keepForever {
+		return "all"
+	}
+	return fmt.Sprintf("%d", limit)
+}
+
+// bucket tracks the remaining slots, the last period key seen, and how many snapshots it has kept
+// so far, for a single retention bucket.
+type bucket struct {
+	limit    int
+	lastSeen string
+	seen     bool
+	kept     int
+}
+
+// keep reports whether a snapshot falling into period key should be retained by this bucket, and
+// if so, records it as the new "last seen" period so subsequent snapshots in the same period are
+// no longer kept by this bucket alone. The returned int is this bucket's 1-based ordinal among the
+// snapshots it has kept (e.g. the 3rd snapshot it ever kept returns 3), for use in reason strings
+// like "hourly#3".
+func (b *bucket) keep(key string) (int, bool) {
+	if b.limit == 0 {
+		return 0, false
+	}
+	if b.seen && b.lastSeen == key {
+		return 0, false
+	}
+	if b.limit != keepForever && b.limit <= 0 {
+		return 0, false
+	}
+	b.seen = true
+	b.lastSeen = key
+	if b.limit != keepForever {
+		b.limit--
+	}
+	b.kept++
+	return b.kept, true
+}
+
+// bucketDecision records what applyKeepBucketsPolicyWithReasons decided for a single snapshot:
+// whether it is kept, and the reason(s) that led to the decision. A kept snapshot can carry more
+// than one reason, since the same snapshot may simultaneously be the latest of its hour, day and
+// week.
+type bucketDecision struct {
+	keep    bool
+	reasons []string
+}
+
+// applyKeepBucketsPolicyWithReasons evaluates the restic-style bucket policy against fullSnaps,
+// which must be sorted newest-to-oldest, and returns a decision, with reasons, for every snapshot.
+// A snapshot is kept as soon as any bucket claims it; keeping it for one reason (e.g. daily) does
+// not prevent it from also being counted towards another bucket (e.g. weekly) if it happens to be
+// the most recent snapshot in both periods, so a single snapshot may legitimately satisfy, and be
+// reported against, multiple buckets (e.g. "hourly#3, weekly#1").
+func applyKeepBucketsPolicyWithReasons(fullSnaps snapstore.SnapList, policy *KeepBucketsPolicy, now time.Time) map[*snapstore.Snapshot]bucketDecision {
+	buckets := map[string]*bucket{
+		"last":    {limit: policy.KeepLast},
+		"hourly":  {limit: policy.KeepHourly},
+		"daily":   {limit: policy.KeepDaily},
+		"weekly":  {limit: policy.KeepWeekly},
+		"monthly": {limit: policy.KeepMonthly},
+		"yearly":  {limit: policy.KeepYearly},
+	}
+
+	decisions := map[*snapstore.Snapshot]bucketDecision{}
+	lastCounter := 0
+	for _, snap := range fullSnaps {
+		createdOn := snap.CreatedOn.UTC()
+
+		var reasons []string
+		if policy.KeepWithin > 0 && now.Sub(createdOn) <= policy.KeepWithin {
+			reasons = append(reasons, fmt.Sprintf("within %s", policy.KeepWithin))
+		}
+
+		lastCounter++
+		if n, ok := buckets["last"].keep(fmt.Sprintf("%d", lastCounter)); ok {
+			reasons = append(reasons, fmt.Sprintf("last#%d", n))
+		}
+		if n, ok := buckets["hourly"].keep(createdOn.Format("2006-01-02-15")); ok {
+			reasons = append(reasons, fmt.Sprintf("hourly#%d", n))
+		}
+		if n, ok := buckets["daily"].keep(createdOn.Format("2006-01-02")); ok {
+			reasons = append(reasons, fmt.Sprintf("daily#%d", n))
+		}
+		if n, ok := buckets["weekly"].keep(isoWeekKey(createdOn)); ok {
+			reasons = append(reasons, fmt.Sprintf("weekly#%d", n))
+		}
+		if n, ok := buckets["monthly"].keep(createdOn.Format("2006-01")); ok {
+			reasons = append(reasons, fmt.Sprintf("monthly#%d", n))
+		}
+		if n, ok := buckets["yearly"].keep(createdOn.Format("2006")); ok {
+			reasons = append(reasons, fmt.Sprintf("yearly#%d", n))
+		}
+
+		if len(reasons) > 0 {
+			decisions[snap] = bucketDecision{keep: true, reasons: reasons}
+			continue
+		}
+		decisions[snap] = bucketDecision{keep: false, reasons: []string{"no bucket matched"}}
+	}
+	return decisions
+}
+
+// isoWeekKey returns a period key identifying the ISO-8601 year+week of t, so that weeks spanning
+// a year boundary are not accidentally merged with the wrong year's week 1/52.
+func isoWeekKey(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+)
+
+func snapAt(name string, createdOn time.Time) *snapstore.Snapshot {
+	return &snapstore.Snapshot{Kind: snapstore.SnapshotKindFull, SnapName: name, CreatedOn: createdOn}
+}
+
+func TestApplyKeepBucketsPolicyWithReasonsKeepsOneLatestSnapshotPerDay(t *testing.T) {
+	now := time.Date(2026, time.July, 25, 12, 0, 0, 0, time.UTC)
+	// Newest-first, one snapshot a day for three days.
+	snaps := []*snapstore.Snapshot{
+		snapAt("day0", now),
+		snapAt("day1", now.AddDate(0, 0, -1)),
+		snapAt("day2", now.AddDate(0, 0, -2)),
+	}
+	policy := &KeepBucketsPolicy{KeepDaily: 2}
+
+	decisions := applyKeepBucketsPolicyWithReasons(snaps, policy, now)
+
+	if !decisions[snaps[0]].keep || !decisions[snaps[1]].keep {
+		t.Fatalf("expected the two most recent days to be kept, got %+v", decisions)
+	}
+	if decisions[snaps[2]].keep {
+		t.Fatalf("expected day2 to be deleted once KeepDaily's 2 slots are used, got %+v", decisions[snaps[2]])
+	}
+}
+
+func TestApplyKeepBucketsPolicyWithReasonsReportsMultipleMatchingBuckets(t *testing.T) {
+	now := time.Date(2026, time.July, 25, 12, 0, 0, 0, time.UTC)
+	snaps := []*snapstore.Snapshot{snapAt("only", now)}
+	policy := &KeepBucketsPolicy{KeepHourly: 1, KeepDaily: 1, KeepWeekly: 1}
+
+	decisions := applyKeepBucketsPolicyWithReasons(snaps, policy, now)
+
+	decision := decisions[snaps[0]]
+	if !decision.keep {
+		t.Fatalf("expected the only snapshot to be kept, got %+v", decision)
+	}
+	if len(decision.reasons) != 3 {
+		t.Fatalf("expected one reason per bucket it satisfies, got %v", decision.reasons)
+	}
+}
+
+func TestApplyKeepBucketsPolicyWithReasonsNoBucketMatched(t *testing.T) {
+	now := time.Date(2026, time.July, 25, 12, 0, 0, 0, time.UTC)
+	snaps := []*snapstore.Snapshot{snapAt("stale", now.AddDate(-1, 0, 0))}
+	policy := &KeepBucketsPolicy{KeepDaily: 0}
+
+	decisions := applyKeepBucketsPolicyWithReasons(snaps, policy, now)
+
+	decision := decisions[snaps[0]]
+	if decision.keep {
+		t.Fatalf("expected stale snapshot to be deleted, got %+v", decision)
+	}
+	if len(decision.reasons) != 1 || decision.reasons[0] != "no bucket matched" {
+		t.Fatalf("expected a single \"no bucket matched\" reason, got %v", decision.reasons)
+	}
+}
+
+func TestApplyKeepBucketsPolicyWithReasonsKeepWithin(t *testing.T) {
+	now := time.Date(2026, time.July, 25, 12, 0, 0, 0, time.UTC)
+	snaps := []*snapstore.Snapshot{snapAt("recent", now.Add(-time.Minute))}
+	policy := &KeepBucketsPolicy{KeepWithin: time.Hour}
+
+	decisions := applyKeepBucketsPolicyWithReasons(snaps, policy, now)
+
+	if !decisions[snaps[0]].keep {
+		t.Fatalf("expected a snapshot within KeepWithin to be kept regardless of bucket limits, got %+v", decisions[snaps[0]])
+	}
+}
+
+func TestIsoWeekKeyDistinguishesYearBoundary(t *testing.T) {
+	dec31 := time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC) // ISO week 1 of 2025
+	jan1 := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)    // also ISO week 1 of 2025
+
+	if isoWeekKey(dec31) != isoWeekKey(jan1) {
+		t.Fatalf("expected Dec 31 2024 and Jan 1 2025 to share an ISO week key, got %q and %q", isoWeekKey(dec31), isoWeekKey(jan1))
+	}
+
+	dec30 := time.Date(2024, time.December, 30, 0, 0, 0, 0, time.UTC) // ISO week 1 of 2025
+	dec29 := time.Date(2024, time.December, 29, 0, 0, 0, 0, time.UTC) // ISO week 52 of 2024
+	if isoWeekKey(dec30) == isoWeekKey(dec29) {
+		t.Fatalf("expected week 52 of 2024 and week 1 of 2025 to have distinct keys, both got %q", isoWeekKey(dec30))
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus metrics for the garbage collector. They are registered on prometheus's default
+// registry on package init, mirroring how the rest of etcdbrctl's metrics are exposed.
+var (
+	gcRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcdbr",
+		Name:      "gc_runs_total",
+		Help:      "Total number of garbage collection runs, by policy and result (success/failure).",
+	}, []string{"policy", "result"})
+
+	gcSnapshotsDeletedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "etcdbr",
+		Name:      "gc_snapshots_deleted_total",
+		Help:      "Total number of snapshots deleted by the garbage collector, by kind (Full/Incr); includes delta snapshots deleted alongside their full snapshot.",
+	}, []string{"kind"})
+
+	gcSnapshotsKept = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcdbr",
+		Name:      "gc_snapshots_kept",
+		Help:      "Number of full snapshots kept by the most recent garbage collection run, by the bucket/reason that kept them.",
+	}, []string{"bucket"})
+
+	gcLastRunDurationSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcdbr",
+		Name:      "gc_last_run_duration_seconds",
+		Help:      "Duration of the most recent garbage collection run, in seconds.",
+	})
+
+	gcLastRunTimestampSeconds = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "etcdbr",
+		Name:      "gc_last_run_timestamp_seconds",
+		Help:      "Unix timestamp of the most recent garbage collection run.",
+	})
+
+	snapshotsTotal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "etcdbr",
+		Name:      "snapshots_total",
+		Help:      "Number of snapshots currently in the snapstore, by kind (Full/Incr), as of the most recent GC listing.",
+	}, []string{"kind"})
+)
+
+func init() {
+	prometheus.MustRegister(gcRunsTotal, gcSnapshotsDeletedTotal, gcSnapshotsKept, gcLastRunDurationSeconds, gcLastRunTimestampSeconds, snapshotsTotal)
+}
+
+// recordGCMetrics updates every GC-related metric from the outcome of a single run. snapList is
+// a listing of the store taken after the run's deletions, used to sample etcdbr_snapshots_total.
+// dryRun must be true when the
+// run only evaluated the policy without deleting anything, so a "deleted" decision is never
+// counted towards etcdbr_gc_snapshots_deleted_total.
+func recordGCMetrics(policy string, report Report, snapList snapstore.SnapList, duration time.Duration, runErr error, dryRun bool) {
+	result := "success"
+	if runErr != nil {
+		result = "failure"
+	}
+	gcRunsTotal.WithLabelValues(policy, result).Inc()
+	gcLastRunDurationSeconds.Set(duration.Seconds())
+	gcLastRunTimestampSeconds.Set(float64(time.Now().UTC().Unix()))
+
+	snapKindCounts := map[string]int{}
+	for _, snap := range snapList {
+		snapKindCounts[string(snap.Kind)]++
+	}
+	for kind, count := range snapKindCounts {
+		snapshotsTotal.WithLabelValues(kind).Set(float64(count))
+	}
+
+	kept := map[string]int{}
+	for _, entry := range report.Entries {
+		switch entry.Decision {
+		case "deleted":
+			if !dryRun && entry.Err == nil {
+				gcSnapshotsDeletedTotal.WithLabelValues(string(entry.Snap.Kind)).Inc()
+				for _, delta := range entry.Deltas {
+					gcSnapshotsDeletedTotal.WithLabelValues(string(delta.Kind)).Inc()
+				}
+			}
+		case "kept":
+			bucket := "unknown"
+			if len(entry.Reasons) > 0 {
+				bucket = keptBucketName(entry.Reasons[0])
+			}
+			kept[bucket]++
+		}
+	}
+	// Reset before Set-ing this run's counts: a bucket present in a past run but not this one
+	// (e.g. KeepHourly lowered via config reload) would otherwise keep reporting its last, now
+	// stale, value forever.
+	gcSnapshotsKept.Reset()
+	for bucket, count := range kept {
+		gcSnapshotsKept.WithLabelValues(bucket).Set(float64(count))
+	}
+}
+
+// keptBucketName strips the per-run ordinal off a reason string (e.g. "hourly#17" -> "hourly") so
+// it's safe to use as a Prometheus label value. The ordinal differs for every snapshot a bucket
+// keeps within a run (up to the bucket's limit, or to the number of snapshots for an unlimited
+// bucket); the bucket name it belongs to does not. The full reason, ordinal included, is still
+// used verbatim in logs and GC history.
+func keptBucketName(reason string) string {
+	if i := strings.IndexByte(reason, '#'); i >= 0 {
+		return reason[:i]
+	}
+	return reason
+}
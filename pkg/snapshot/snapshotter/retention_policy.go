@@ -0,0 +1,210 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+)
+
+// RetentionPolicy decides, out of a list of full snapshots, which to keep and which to delete.
+// fullSnaps is sorted oldest-first, the same ordering snapstore.SnapStore.List returns.
+//
+// reasons maps a snapshot's SnapName to the human-readable bucket(s) that kept or deleted it
+// (e.g. "hourly#3", "weekly#1", "no bucket matched"), so callers can emit an audit log line per
+// snapshot without needing to know the policy's internals.
+type RetentionPolicy interface {
+	Apply(fullSnaps snapstore.SnapList, now time.Time) (keep, delete snapstore.SnapList, reasons map[string][]string)
+}
+
+// ExponentialPolicy implements the original hard-coded 24-hourly/7-daily/4-weekly schedule:
+// everything in the current hour is kept, then the latest snapshot per hour for 24 hours, the
+// latest per day for 7 days, the latest per week for 4 weeks, and nothing older than that.
+type ExponentialPolicy struct {
+	HourModeLimit int
+	DayModeLimit  int
+	WeekModeLimit int
+}
+
+// NewExponentialPolicy returns the default 24-hourly/7-daily/4-weekly ExponentialPolicy.
+func NewExponentialPolicy() *ExponentialPolicy {
+	return &ExponentialPolicy{HourModeLimit: 24, DayModeLimit: 7, WeekModeLimit: 4}
+}
+
+// Apply implements RetentionPolicy. The latest full snapshot is always kept by the caller before
+// fullSnaps ever reaches here (mirroring the original "keep the last snapstream" behaviour), so
+// this only has to decide on the remaining, older snapshots.
+func (p *ExponentialPolicy) Apply(fullSnaps snapstore.SnapList, now time.Time) (keep, delete snapstore.SnapList, reasons map[string][]string) {
+	reasons = map[string][]string{}
+	var (
+		backupMode  = "None"
+		backupCount = -1
+	)
+
+	for i := len(fullSnaps) - 1; i >= 0; i-- {
+		snap := fullSnaps[i]
+		deleteSnap := true
+
+	redo:
+		switch backupMode {
+		case "None":
+			deleteSnap = false
+			if now.Truncate(time.Hour).Equal(snap.CreatedOn.Truncate(time.Hour)) {
+				break
+			}
+			backupMode = "Hour"
+			backupCount = p.HourModeLimit - 1
+			goto redo
+
+		case "Hour":
+			for backupCount >= 0 {
+				rounded := time.Date(now.Year(), now.Month(), now.Day(), backupCount, 0, 0, 0, now.Location())
+				diff := rounded.Sub(snap.CreatedOn.Truncate(time.Hour))
+				if diff == 0 {
+					deleteSnap = false
+					reasons[snap.SnapName] = append(reasons[snap.SnapName], fmt.Sprintf("hourly#%d", p.HourModeLimit-backupCount))
+					backupCount--
+					if backupCount == -1 {
+						backupMode = "Day"
+						backupCount = p.DayModeLimit - 1
+					}
+					break
+				} else if diff > 0 {
+					backupCount--
+				} else {
+					deleteSnap = true
+					break
+				}
+			}
+			if backupCount >= 0 || backupMode == "Day" {
+				break
+			}
+			backupMode = "Day"
+			backupCount = p.DayModeLimit - 1
+			goto redo
+
+		case "Day":
+			for backupCount >= 0 {
+				rounded := time.Date(now.Year(), now.Month(), now.Day()-7+backupCount, 0, 0, 0, 0, now.Location())
+				diff := rounded.Sub(snap.CreatedOn.Truncate(time.Hour * 24))
+				if diff == 0 {
+					deleteSnap = false
+					reasons[snap.SnapName] = append(reasons[snap.SnapName], fmt.Sprintf("daily#%d", p.DayModeLimit-backupCount))
+					backupCount--
+					if backupCount == -1 {
+						backupMode = "Week"
+						backupCount = p.WeekModeLimit - 1
+					}
+					break
+				} else if diff > 0 {
+					backupCount--
+				} else {
+					deleteSnap = true
+					break
+				}
+			}
+			if backupCount >= 0 || backupMode == "Week" {
+				break
+			}
+			backupMode = "Week"
+			backupCount = p.WeekModeLimit - 1
+			goto redo
+
+		case "Week":
+			for backupCount >= 0 {
+				rounded := time.Date(now.Year(), now.Month(), now.Day()-p.DayModeLimit-7*(p.WeekModeLimit-backupCount), 0, 0, 0, 0, now.Location())
+				diff := int(rounded.Sub(snap.CreatedOn.Truncate(time.Hour)).Hours()/24) / 7
+				if diff == 0 {
+					deleteSnap = false
+					reasons[snap.SnapName] = append(reasons[snap.SnapName], fmt.Sprintf("weekly#%d", p.WeekModeLimit-backupCount))
+					backupCount--
+					if backupCount == -1 {
+						backupMode = "Month"
+					}
+					break
+				} else if diff > 0 {
+					backupCount--
+				} else {
+					deleteSnap = true
+					break
+				}
+			}
+			if backupCount >= 0 || backupMode == "Month" {
+				break
+			}
+			backupMode = "Month"
+			goto redo
+
+		case "Month":
+			deleteSnap = true
+		}
+
+		if deleteSnap {
+			reasons[snap.SnapName] = append(reasons[snap.SnapName], "no bucket matched")
+			delete = append(delete, snap)
+		} else {
+			keep = append(keep, snap)
+		}
+	}
+	return keep, delete, reasons
+}
+
+// GarbageCollectionPolicyLimitBased is a simple retention policy that keeps only the most recent
+// MaxBackups full snapshots, regardless of their age.
+const GarbageCollectionPolicyLimitBased = "LimitBased"
+
+// LimitBasedPolicy keeps only the most recent MaxBackups full snapshots.
+type LimitBasedPolicy struct {
+	MaxBackups int
+}
+
+// Apply implements RetentionPolicy.
+func (p *LimitBasedPolicy) Apply(fullSnaps snapstore.SnapList, now time.Time) (keep, delete snapstore.SnapList, reasons map[string][]string) {
+	reasons = map[string][]string{}
+	for i, snap := range fullSnaps {
+		// fullSnaps is oldest-first; keep the MaxBackups newest, i.e. the highest indices.
+		if i >= len(fullSnaps)-p.MaxBackups {
+			reasons[snap.SnapName] = append(reasons[snap.SnapName], fmt.Sprintf("within last %d", p.MaxBackups))
+			keep = append(keep, snap)
+		} else {
+			reasons[snap.SnapName] = append(reasons[snap.SnapName], fmt.Sprintf("beyond limit of %d", p.MaxBackups))
+			delete = append(delete, snap)
+		}
+	}
+	return keep, delete, reasons
+}
+
+// Apply implements RetentionPolicy for the restic-style bucket policy.
+func (p *KeepBucketsPolicy) Apply(fullSnaps snapstore.SnapList, now time.Time) (keep, delete snapstore.SnapList, reasons map[string][]string) {
+	// applyKeepBucketsPolicyWithReasons expects newest-first; fullSnaps is oldest-first.
+	reversed := make(snapstore.SnapList, len(fullSnaps))
+	for i, snap := range fullSnaps {
+		reversed[len(fullSnaps)-1-i] = snap
+	}
+	decisions := applyKeepBucketsPolicyWithReasons(reversed, p, now)
+	reasons = map[string][]string{}
+	for _, snap := range fullSnaps {
+		decision := decisions[snap]
+		reasons[snap.SnapName] = decision.reasons
+		if decision.keep {
+			keep = append(keep, snap)
+		} else {
+			delete = append(delete, snap)
+		}
+	}
+	return keep, delete, reasons
+}
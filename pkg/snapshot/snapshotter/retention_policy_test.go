@@ -0,0 +1,102 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+)
+
+func TestLimitBasedPolicyKeepsNewestMaxBackups(t *testing.T) {
+	now := time.Now()
+	// oldest-first, as RetentionPolicy.Apply expects.
+	snaps := snapstore.SnapList{
+		snapAt("oldest", now.AddDate(0, 0, -2)),
+		snapAt("middle", now.AddDate(0, 0, -1)),
+		snapAt("newest", now),
+	}
+	policy := &LimitBasedPolicy{MaxBackups: 2}
+
+	keep, del, reasons := policy.Apply(snaps, now)
+
+	if len(keep) != 2 || keep[0].SnapName != "middle" || keep[1].SnapName != "newest" {
+		t.Fatalf("expected the 2 newest snapshots kept, got %v", keep)
+	}
+	if len(del) != 1 || del[0].SnapName != "oldest" {
+		t.Fatalf("expected the oldest snapshot deleted, got %v", del)
+	}
+	if len(reasons["oldest"]) == 0 || len(reasons["newest"]) == 0 {
+		t.Fatalf("expected a reason recorded for every snapshot, got %v", reasons)
+	}
+}
+
+func TestKeepBucketsPolicyApplyReversesToNewestFirstAndBack(t *testing.T) {
+	now := time.Date(2026, time.July, 25, 12, 0, 0, 0, time.UTC)
+	// oldest-first, as RetentionPolicy.Apply expects; one snapshot per day for 3 days.
+	snaps := snapstore.SnapList{
+		snapAt("day2", now.AddDate(0, 0, -2)),
+		snapAt("day1", now.AddDate(0, 0, -1)),
+		snapAt("day0", now),
+	}
+	policy := &KeepBucketsPolicy{KeepDaily: 2}
+
+	keep, del, reasons := policy.Apply(snaps, now)
+
+	if len(keep) != 2 || len(del) != 1 {
+		t.Fatalf("expected 2 kept and 1 deleted, got keep=%v del=%v", keep, del)
+	}
+	if del[0].SnapName != "day2" {
+		t.Fatalf("expected the oldest day to be the one deleted, got %v", del)
+	}
+	// Order is preserved oldest-first, matching the fullSnaps input order.
+	if keep[0].SnapName != "day1" || keep[1].SnapName != "day0" {
+		t.Fatalf("expected kept snapshots in oldest-first order, got %v", keep)
+	}
+	if len(reasons["day1"]) == 0 || len(reasons["day0"]) == 0 {
+		t.Fatalf("expected kept snapshots to carry a reason, not just deleted ones, got %v", reasons)
+	}
+	if reasons["day2"][0] != "no bucket matched" {
+		t.Fatalf("expected the deleted snapshot's reason to be \"no bucket matched\", got %v", reasons["day2"])
+	}
+}
+
+func TestExponentialPolicyKeepsCurrentHour(t *testing.T) {
+	now := time.Now()
+	snaps := snapstore.SnapList{snapAt("this-hour", now)}
+	policy := NewExponentialPolicy()
+
+	keep, del, _ := policy.Apply(snaps, now)
+
+	if len(keep) != 1 || len(del) != 0 {
+		t.Fatalf("expected the current-hour snapshot to be kept, got keep=%v del=%v", keep, del)
+	}
+}
+
+func TestExponentialPolicyDropsSnapshotsOlderThanAllModes(t *testing.T) {
+	policy := &ExponentialPolicy{HourModeLimit: 1, DayModeLimit: 1, WeekModeLimit: 1}
+	now := time.Now()
+	snaps := snapstore.SnapList{snapAt("ancient", now.AddDate(-1, 0, 0))}
+
+	keep, del, reasons := policy.Apply(snaps, now)
+
+	if len(keep) != 0 || len(del) != 1 {
+		t.Fatalf("expected the ancient snapshot to be deleted, got keep=%v del=%v", keep, del)
+	}
+	if len(reasons["ancient"]) == 0 {
+		t.Fatalf("expected a reason recorded for the deleted snapshot, got %v", reasons)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+	"github.com/sirupsen/logrus"
+)
+
+// Config holds everything GarbageCollector needs to evaluate and enforce a retention policy on a
+// schedule. It is the single place an operator-facing command (the long-running server, or
+// `snapshot prune --watch`) configures the periodic collector, so the flags it reads from and the
+// fields GarbageCollector reads from can never drift apart.
+type Config struct {
+	Store       snapstore.SnapStore
+	StoreConfig *snapstore.Config
+	Logger      *logrus.Entry
+
+	// GarbageCollectionPeriodSeconds is how often GarbageCollector evaluates the retention policy.
+	GarbageCollectionPeriodSeconds time.Duration
+	// GarbageCollectionPolicy selects the RetentionPolicy: GarbageCollectionPolicyKeepBuckets,
+	// GarbageCollectionPolicyLimitBased, or anything else for the default ExponentialPolicy.
+	GarbageCollectionPolicy string
+	// MaxBackups is the limit enforced by the LimitBased policy.
+	MaxBackups int
+	// KeepBucketsPolicy is the restic-style policy enforced when GarbageCollectionPolicy is
+	// GarbageCollectionPolicyKeepBuckets. If it is nil in that case, GarbageCollector logs a
+	// warning and falls back to the default ExponentialPolicy.
+	KeepBucketsPolicy *KeepBucketsPolicy
+	// GroupBy partitions snapshots (e.g. by etcd cluster/member) before the policy is applied to
+	// each group independently. Nil evaluates every snapshot as a single group.
+	GroupBy GroupByFunc
+	// KeepTags exempts any snapshot matching at least one TagList from deletion, regardless of
+	// what the retention policy decides.
+	KeepTags []snapstore.TagList
+	// LowPrioritySubprocessThreshold is the minimum number of snapshots a single GC run must
+	// delete before GarbageCollector hands the deletions off to the low OS scheduling priority
+	// `internal gc-worker` subprocess instead of deleting them in-process. 0 disables the
+	// subprocess entirely.
+	LowPrioritySubprocessThreshold int
+	// GCWorkerExecutable overrides the executable re-exec'd into `internal gc-worker`; empty uses
+	// the running process's own executable (os.Executable()).
+	GCWorkerExecutable string
+}
+
+// Snapshotter drives the periodic garbage collection loop against a single snapstore.SnapStore.
+// Its fields are exactly what GarbageCollector and its helpers (in garbagecollector.go,
+// subprocess.go) read from; construct one with NewSnapshotter rather than setting fields directly.
+type Snapshotter struct {
+	store       snapstore.SnapStore
+	storeConfig *snapstore.Config
+	logger      *logrus.Entry
+
+	garbageCollectionPeriodSeconds time.Duration
+	garbageCollectionPolicy        string
+	maxBackups                     int
+	keepBucketsPolicy              *KeepBucketsPolicy
+	groupBy                        GroupByFunc
+	keepTags                       []snapstore.TagList
+	lowPrioritySubprocessThreshold int
+	gcWorkerExecutable             string
+}
+
+// NewSnapshotter returns a Snapshotter configured to run garbage collection per config. A nil
+// Logger defaults to a standalone logrus.Entry so callers that don't otherwise need logrus
+// configured don't have to construct one just to call GarbageCollector.
+func NewSnapshotter(config Config) *Snapshotter {
+	logger := config.Logger
+	if logger == nil {
+		logger = logrus.NewEntry(logrus.StandardLogger())
+	}
+	return &Snapshotter{
+		store:       config.Store,
+		storeConfig: config.StoreConfig,
+		logger:      logger,
+
+		garbageCollectionPeriodSeconds: config.GarbageCollectionPeriodSeconds,
+		garbageCollectionPolicy:        config.GarbageCollectionPolicy,
+		maxBackups:                     config.MaxBackups,
+		keepBucketsPolicy:              config.KeepBucketsPolicy,
+		groupBy:                        config.GroupBy,
+		keepTags:                       config.KeepTags,
+		lowPrioritySubprocessThreshold: config.LowPrioritySubprocessThreshold,
+		gcWorkerExecutable:             config.GCWorkerExecutable,
+	}
+}
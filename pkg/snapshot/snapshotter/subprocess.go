@@ -0,0 +1,169 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+)
+
+// GCWorkerRequest is the JSON payload streamed over stdin to the `etcdbrctl internal gc-worker`
+// child process: everything it needs to re-open the snapstore and carry out a precomputed
+// deletion plan without re-evaluating the retention policy itself.
+type GCWorkerRequest struct {
+	StoreConfig *snapstore.Config  `json:"storeConfig"`
+	Deletions   []GCWorkerDeletion `json:"deletions"`
+}
+
+// GCWorkerDeletion is a single full snapshot, and the delta snapshots chained onto it, that the
+// gc-worker child process should delete.
+type GCWorkerDeletion struct {
+	Snap   *snapstore.Snapshot `json:"snap"`
+	Deltas snapstore.SnapList  `json:"deltas"`
+}
+
+// GCWorkerProgress is a single line of newline-delimited JSON the gc-worker child process writes
+// to stdout after it finishes processing one GCWorkerDeletion.
+type GCWorkerProgress struct {
+	SnapName string `json:"snapName"`
+	Err      string `json:"err,omitempty"`
+}
+
+// executeDeletionsViaSubprocess hands the "deleted" entries of report to a low OS scheduling
+// priority `etcdbrctl internal gc-worker` child process (ioprio idle class / nice +19 on Linux,
+// best-effort no-op elsewhere), so that a large GC pass does not compete with the main process for
+// I/O and CPU while it keeps taking incremental snapshots. It blocks until the child is done or
+// stopCh fires; on stop it lets the child keep running in the background rather than killing it
+// mid-delete, since an interrupted store.Delete sequence is not resumable. If the child cannot be
+// started at all, it falls back to ExecuteDeletions in-process.
+func (ssr *Snapshotter) executeDeletionsViaSubprocess(report Report, stopCh <-chan bool) Report {
+	deletionNames := map[string]bool{}
+	req := GCWorkerRequest{StoreConfig: ssr.storeConfig}
+	for i := range report.Entries {
+		entry := &report.Entries[i]
+		if entry.Decision != "deleted" {
+			continue
+		}
+		req.Deletions = append(req.Deletions, GCWorkerDeletion{Snap: entry.Snap, Deltas: entry.Deltas})
+		deletionNames[entry.Snap.SnapName] = true
+	}
+	if len(req.Deletions) == 0 {
+		return report
+	}
+
+	executable := ssr.gcWorkerExecutable
+	if executable == "" {
+		var err error
+		executable, err = os.Executable()
+		if err != nil {
+			ssr.logger.Warnf("GC: Failed to resolve own executable for low-priority GC subprocess, falling back to in-process deletion: %v", err)
+			return ExecuteDeletions(ssr.store, report)
+		}
+	}
+
+	cmd := exec.Command(executable, "internal", "gc-worker")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		ssr.logger.Warnf("GC: Failed to open stdin for low-priority GC subprocess, falling back to in-process deletion: %v", err)
+		return ExecuteDeletions(ssr.store, report)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		ssr.logger.Warnf("GC: Failed to open stdout for low-priority GC subprocess, falling back to in-process deletion: %v", err)
+		return ExecuteDeletions(ssr.store, report)
+	}
+	cmd.Stderr = os.Stderr
+
+	ssr.logger.Infof("GC: Deleting %d full snapshots via low-priority subprocess", len(req.Deletions))
+	if err := cmd.Start(); err != nil {
+		ssr.logger.Warnf("GC: Failed to start low-priority GC subprocess, falling back to in-process deletion: %v", err)
+		return ExecuteDeletions(ssr.store, report)
+	}
+
+	go func() {
+		defer stdin.Close()
+		if err := json.NewEncoder(stdin).Encode(req); err != nil {
+			ssr.logger.Warnf("GC: Failed to stream deletion plan to GC subprocess: %v", err)
+		}
+	}()
+
+	// confirmed is only ever written to by the decode goroutine below and only ever read after
+	// that goroutine has signalled completion on waitDone, so no further synchronization is
+	// needed for it.
+	confirmed := map[string]error{}
+	waitDone := make(chan error, 1)
+	go func() {
+		decoder := json.NewDecoder(stdout)
+		for {
+			var progress GCWorkerProgress
+			if err := decoder.Decode(&progress); err != nil {
+				break
+			}
+			var progressErr error
+			if progress.Err != "" {
+				progressErr = errors.New(progress.Err)
+			}
+			confirmed[progress.SnapName] = progressErr
+		}
+		waitDone <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-waitDone:
+		if err != nil {
+			ssr.logger.Warnf("GC: Low-priority GC subprocess exited with error: %v", err)
+		}
+		applySubprocessResults(report, deletionNames, confirmed)
+	case <-stopCh:
+		// Killing the child mid-delete would leave it unclear which snapshots were actually
+		// removed, so we let it finish in the background and reap it without blocking the
+		// caller, rather than killing it. Since the report returned below must stop changing the
+		// moment it is handed back, none of these deletions can be called confirmed yet; the next
+		// GC pass will simply see whatever state the child left behind.
+		ssr.logger.Infoln("GC: Stop signal received while low-priority GC subprocess is running; letting it finish in the background.")
+		applySubprocessResults(report, deletionNames, nil)
+		go func() {
+			if err := <-waitDone; err != nil {
+				ssr.logger.Warnf("GC: Low-priority GC subprocess exited with error: %v", err)
+			}
+		}()
+	}
+	return report
+}
+
+// applySubprocessResults records, on report's own entries, the outcome of every deletion the
+// subprocess was asked to perform. confirmed maps a snapshot name to the error (nil on success)
+// the subprocess reported for it; a nil confirmed map, or a deletion absent from it, means the
+// subprocess never got to confirm that deletion (crash, OOM-kill, stop signal, stdout decode
+// error mid-stream), and is recorded as failed/unknown rather than silently treated as a success.
+func applySubprocessResults(report Report, deletionNames map[string]bool, confirmed map[string]error) {
+	for i := range report.Entries {
+		entry := &report.Entries[i]
+		if !deletionNames[entry.Snap.SnapName] {
+			continue
+		}
+		resultErr, ok := confirmed[entry.Snap.SnapName]
+		if !ok {
+			entry.Err = fmt.Errorf("low-priority GC subprocess never confirmed this deletion")
+			continue
+		}
+		entry.Err = resultErr
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshotter
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gardener/etcd-backup-restore/pkg/snapstore"
+)
+
+func TestTagSnapshotAccumulatesTagsAcrossCalls(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tag-snapshot-")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	store, err := snapstore.NewLocalSnapStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create LocalSnapStore: %v", err)
+	}
+
+	snap := snapstore.Snapshot{Kind: snapstore.SnapshotKindFull, CreatedOn: time.Now(), SnapName: "full-1"}
+	if err := store.Save(snap, ioutil.NopCloser(strings.NewReader("data"))); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+
+	if err := TagSnapshot(store, &snapstore.Snapshot{SnapName: "full-1"}, "release"); err != nil {
+		t.Fatalf("failed to tag snapshot: %v", err)
+	}
+	if err := TagSnapshot(store, &snapstore.Snapshot{SnapName: "full-1"}, "pre-upgrade"); err != nil {
+		t.Fatalf("failed to tag snapshot: %v", err)
+	}
+
+	snapList, err := store.List()
+	if err != nil {
+		t.Fatalf("failed to list snapshots: %v", err)
+	}
+	if len(snapList) != 1 {
+		t.Fatalf("expected a single snapshot, got %v", snapList)
+	}
+	tags := snapList[0].Tags
+	if len(tags) != 2 || tags[0] != "release" || tags[1] != "pre-upgrade" {
+		t.Fatalf("expected both tags to accumulate, got %v", tags)
+	}
+}
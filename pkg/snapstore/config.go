@@ -0,0 +1,58 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// ProviderLocal is the Config.Provider value selecting LocalSnapStore.
+const ProviderLocal = "Local"
+
+// Config holds everything needed to open the configured SnapStore backend. It is the shared
+// flag/config surface between the long-running snapshotter and the one-shot `etcdbrctl snapshot
+// prune` command and `internal gc-worker` subprocess, so all three always talk to the same store.
+type Config struct {
+	// Provider selects the backend implementation. Defaults to Local; GetSnapStore supports only
+	// Local and returns an error for any other provider.
+	Provider string
+	// Container is the bucket/container holding the snapshots for a remote provider, or the root
+	// directory for Local.
+	Container string
+	// Prefix is the path prefix under Container holding the snapshots.
+	Prefix string
+}
+
+// GetSnapStore opens the SnapStore backend selected by config. A nil config is treated the same
+// as an empty one, i.e. a Local store rooted at the current directory.
+func GetSnapStore(config *Config) (SnapStore, error) {
+	if config == nil {
+		config = &Config{}
+	}
+	switch config.Provider {
+	case ProviderLocal, "":
+		dir := config.Container
+		if dir == "" {
+			dir = "."
+		}
+		if config.Prefix != "" {
+			dir = filepath.Join(dir, config.Prefix)
+		}
+		return NewLocalSnapStore(dir)
+	default:
+		return nil, fmt.Errorf("unsupported snapstore provider: %q", config.Provider)
+	}
+}
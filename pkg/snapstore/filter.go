@@ -0,0 +1,96 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore
+
+import "time"
+
+// TagList is an AND-set of tags: a snapshot matches a TagList only if it carries every tag in it.
+type TagList []string
+
+// matches reports whether snap carries every tag in tl.
+func (tl TagList) matches(snap *Snapshot) bool {
+	for _, want := range tl {
+		found := false
+		for _, have := range snap.Tags {
+			if have == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesAny reports whether snap satisfies at least one of the given TagLists, i.e. the TagLists
+// are OR-ed together while the tags within a single TagList are AND-ed.
+func MatchesAny(snap *Snapshot, tagLists []TagList) bool {
+	for _, tl := range tagLists {
+		if tl.matches(snap) {
+			return true
+		}
+	}
+	return false
+}
+
+// SnapshotFilter describes the criteria accepted by FilterSnapshots. A zero-value field is not
+// applied, e.g. an empty Tags means "don't filter by tag".
+type SnapshotFilter struct {
+	Tags  []TagList
+	Kind  SnapshotKind
+	Since time.Time
+	Until time.Time
+}
+
+// FilterSnapshots returns the subset of snapList matching every non-zero criterion in filter.
+func FilterSnapshots(snapList SnapList, filter SnapshotFilter) SnapList {
+	var result SnapList
+	for _, snap := range snapList {
+		if len(filter.Tags) > 0 && !MatchesAny(snap, filter.Tags) {
+			continue
+		}
+		if filter.Kind != "" && snap.Kind != filter.Kind {
+			continue
+		}
+		if !filter.Since.IsZero() && snap.CreatedOn.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && snap.CreatedOn.After(filter.Until) {
+			continue
+		}
+		result = append(result, snap)
+	}
+	return result
+}
+
+// FilteringSnapStore wraps a SnapStore and restricts List to the snapshots matching Filter,
+// leaving Fetch/Save/Delete/SetMetadata untouched. It lets a command (e.g. `snapshot prune
+// --since/--until/--kind`) operate against a restricted view of the store without changing what
+// the underlying store actually contains or teaching every RetentionPolicy about filtering.
+type FilteringSnapStore struct {
+	SnapStore
+	Filter SnapshotFilter
+}
+
+// List implements SnapStore.
+func (s FilteringSnapStore) List() (SnapList, error) {
+	snapList, err := s.SnapStore.List()
+	if err != nil {
+		return nil, err
+	}
+	return FilterSnapshots(snapList, s.Filter), nil
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTagListMatches(t *testing.T) {
+	snap := &Snapshot{Tags: []string{"release", "pre-upgrade"}}
+
+	if !(TagList{"release"}).matches(snap) {
+		t.Fatal("expected a snapshot carrying the tag to match")
+	}
+	if !(TagList{"release", "pre-upgrade"}).matches(snap) {
+		t.Fatal("expected a snapshot carrying every tag in the list to match")
+	}
+	if (TagList{"release", "missing"}).matches(snap) {
+		t.Fatal("expected a snapshot missing one of the AND-ed tags not to match")
+	}
+	if !(TagList{}).matches(snap) {
+		t.Fatal("expected an empty TagList to match trivially")
+	}
+}
+
+func TestMatchesAnyOrsTagLists(t *testing.T) {
+	snap := &Snapshot{Tags: []string{"release"}}
+
+	if !MatchesAny(snap, []TagList{{"pre-upgrade"}, {"release"}}) {
+		t.Fatal("expected MatchesAny to find the matching TagList among several")
+	}
+	if MatchesAny(snap, []TagList{{"pre-upgrade"}, {"nightly"}}) {
+		t.Fatal("expected MatchesAny to reject when no TagList matches")
+	}
+	if MatchesAny(snap, nil) {
+		t.Fatal("expected MatchesAny to reject when there are no TagLists to satisfy")
+	}
+}
+
+func TestFilterSnapshots(t *testing.T) {
+	t0 := time.Date(2026, time.July, 20, 0, 0, 0, 0, time.UTC)
+	snapList := SnapList{
+		{SnapName: "full-tagged", Kind: SnapshotKindFull, CreatedOn: t0, Tags: []string{"release"}},
+		{SnapName: "full-untagged", Kind: SnapshotKindFull, CreatedOn: t0.AddDate(0, 0, 1)},
+		{SnapName: "delta", Kind: SnapshotKindDelta, CreatedOn: t0.AddDate(0, 0, 1)},
+	}
+
+	byTag := FilterSnapshots(snapList, SnapshotFilter{Tags: []TagList{{"release"}}})
+	if len(byTag) != 1 || byTag[0].SnapName != "full-tagged" {
+		t.Fatalf("expected only the tagged snapshot, got %v", byTag)
+	}
+
+	byKind := FilterSnapshots(snapList, SnapshotFilter{Kind: SnapshotKindDelta})
+	if len(byKind) != 1 || byKind[0].SnapName != "delta" {
+		t.Fatalf("expected only the delta snapshot, got %v", byKind)
+	}
+
+	bySince := FilterSnapshots(snapList, SnapshotFilter{Since: t0.AddDate(0, 0, 1)})
+	if len(bySince) != 2 {
+		t.Fatalf("expected the two snapshots on or after Since, got %v", bySince)
+	}
+
+	unfiltered := FilterSnapshots(snapList, SnapshotFilter{})
+	if len(unfiltered) != len(snapList) {
+		t.Fatalf("expected a zero-value filter to pass every snapshot through, got %v", unfiltered)
+	}
+}
+
+type listOnlyStore struct {
+	SnapStore
+	snapList SnapList
+}
+
+func (s listOnlyStore) List() (SnapList, error) {
+	return s.snapList, nil
+}
+
+func TestFilteringSnapStoreRestrictsList(t *testing.T) {
+	snapList := SnapList{
+		{SnapName: "full", Kind: SnapshotKindFull},
+		{SnapName: "delta", Kind: SnapshotKindDelta},
+	}
+	store := FilteringSnapStore{
+		SnapStore: listOnlyStore{snapList: snapList},
+		Filter:    SnapshotFilter{Kind: SnapshotKindFull},
+	}
+
+	got, err := store.List()
+	if err != nil {
+		t.Fatalf("failed to list: %v", err)
+	}
+	if len(got) != 1 || got[0].SnapName != "full" {
+		t.Fatalf("expected only the full snapshot, got %v", got)
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// metaFileSuffix names the sidecar file a LocalSnapStore writes next to a snapshot object to carry
+// its metadata (kind, revisions, tags, labels, ...); see SnapStore.SetMetadata for why this is
+// kept separate from the snapshot object itself.
+const metaFileSuffix = ".meta.json"
+
+// LocalSnapStore is a SnapStore backed by a directory on the local filesystem, rooted at dir. It
+// exists mainly to give the CLI and tests a SnapStore they can exercise without talking to an
+// object store.
+type LocalSnapStore struct {
+	dir string
+}
+
+// NewLocalSnapStore returns a LocalSnapStore rooted at dir, creating it if it does not yet exist.
+func NewLocalSnapStore(dir string) (*LocalSnapStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &LocalSnapStore{dir: dir}, nil
+}
+
+func (s *LocalSnapStore) path(snap Snapshot) string {
+	return filepath.Join(s.dir, snap.SnapDir, snap.SnapName)
+}
+
+// Fetch implements SnapStore.
+func (s *LocalSnapStore) Fetch(snap Snapshot) (ReadCloser, error) {
+	return os.Open(s.path(snap))
+}
+
+// List implements SnapStore. It discovers snapshots from their metadata sidecar files rather than
+// the data files themselves, so listing never has to open, and pay the cost of, the full snapshot
+// body. The result is sorted oldest-first by CreatedOn, per SnapList's contract; filepath.Walk
+// itself only guarantees lexical path order, which does not track CreatedOn for every naming
+// scheme.
+func (s *LocalSnapStore) List() (SnapList, error) {
+	var snapList SnapList
+	err := filepath.Walk(s.dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, metaFileSuffix) {
+			return nil
+		}
+		data, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var snap Snapshot
+		if err := json.Unmarshal(data, &snap); err != nil {
+			return err
+		}
+		if snap.Kind != SnapshotKindFull && snap.Kind != SnapshotKindDelta {
+			// Not an actual snapshot object (e.g. the GC history blob, saved the same way but
+			// with no Kind set); List only ever returns real snapshots.
+			return nil
+		}
+		snapList = append(snapList, &snap)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snapList, func(i, j int) bool { return snapList[i].CreatedOn.Before(snapList[j].CreatedOn) })
+	return snapList, nil
+}
+
+// Save implements SnapStore.
+func (s *LocalSnapStore) Save(snap Snapshot, rc ReadCloser) error {
+	p := s.path(snap)
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer rc.Close()
+	if _, err := io.Copy(f, rc); err != nil {
+		return err
+	}
+	return s.writeMeta(snap)
+}
+
+// Delete implements SnapStore.
+func (s *LocalSnapStore) Delete(snap Snapshot) error {
+	if err := os.Remove(s.path(snap)); err != nil {
+		return err
+	}
+	return os.Remove(s.path(snap) + metaFileSuffix)
+}
+
+// SetMetadata implements SnapStore by updating the Tags field of the existing metadata sidecar in
+// place, leaving every other field (Kind, CreatedOn, Labels, ...) untouched. It reads the sidecar
+// first rather than marshaling snap as given, since callers (e.g. `etcdbrctl snapshot tag`) only
+// ever have the SnapDir/SnapName of the snapshot being tagged, not its full metadata.
+func (s *LocalSnapStore) SetMetadata(snap Snapshot, tags []string) error {
+	if _, err := os.Stat(s.path(snap)); err != nil {
+		return err
+	}
+	existing, err := s.readMeta(snap)
+	if err != nil {
+		return err
+	}
+	existing.Tags = tags
+	return s.writeMeta(existing)
+}
+
+func (s *LocalSnapStore) readMeta(snap Snapshot) (Snapshot, error) {
+	data, err := ioutil.ReadFile(s.path(snap) + metaFileSuffix)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var meta Snapshot
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Snapshot{}, err
+	}
+	return meta, nil
+}
+
+func (s *LocalSnapStore) writeMeta(snap Snapshot) error {
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path(snap)+metaFileSuffix, data, 0600)
+}
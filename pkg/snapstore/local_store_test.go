@@ -0,0 +1,125 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalSnapStoreSaveThenList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-snapstore-list-")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+
+	store, err := NewLocalSnapStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create LocalSnapStore: %v", err)
+	}
+
+	snap := Snapshot{
+		Kind:      SnapshotKindFull,
+		CreatedOn: time.Date(2026, time.July, 20, 0, 0, 0, 0, time.UTC),
+		SnapDir:   "v1",
+		SnapName:  "full-1",
+	}
+	if err := store.Save(snap, ioutil.NopCloser(strings.NewReader("data"))); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+
+	snapList, err := store.List()
+	if err != nil {
+		t.Fatalf("failed to list snapshots: %v", err)
+	}
+	if len(snapList) != 1 || snapList[0].SnapName != "full-1" {
+		t.Fatalf("expected List to find the saved snapshot, got %v", snapList)
+	}
+}
+
+func TestLocalSnapStoreListSortsByCreatedOnNotName(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-snapstore-order-")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+
+	store, err := NewLocalSnapStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create LocalSnapStore: %v", err)
+	}
+
+	// "a-newer" sorts before "b-older" lexically, but was created after it; List must still
+	// return b-older first.
+	newer := Snapshot{Kind: SnapshotKindFull, CreatedOn: time.Date(2026, time.July, 20, 0, 0, 0, 0, time.UTC), SnapName: "a-newer"}
+	older := Snapshot{Kind: SnapshotKindFull, CreatedOn: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), SnapName: "b-older"}
+	if err := store.Save(newer, ioutil.NopCloser(strings.NewReader("data"))); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+	if err := store.Save(older, ioutil.NopCloser(strings.NewReader("data"))); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+
+	snapList, err := store.List()
+	if err != nil {
+		t.Fatalf("failed to list snapshots: %v", err)
+	}
+	if len(snapList) != 2 || snapList[0].SnapName != "b-older" || snapList[1].SnapName != "a-newer" {
+		t.Fatalf("expected List to return b-older before a-newer, got %v", snapList)
+	}
+}
+
+func TestLocalSnapStoreSetMetadataPreservesExistingFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "local-snapstore-setmetadata-")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+
+	store, err := NewLocalSnapStore(dir)
+	if err != nil {
+		t.Fatalf("failed to create LocalSnapStore: %v", err)
+	}
+
+	createdOn := time.Date(2026, time.July, 20, 0, 0, 0, 0, time.UTC)
+	snap := Snapshot{
+		Kind:      SnapshotKindFull,
+		CreatedOn: createdOn,
+		SnapDir:   "v1",
+		SnapName:  "full-1",
+	}
+	if err := store.Save(snap, ioutil.NopCloser(strings.NewReader("data"))); err != nil {
+		t.Fatalf("failed to save snapshot: %v", err)
+	}
+
+	if err := store.SetMetadata(Snapshot{SnapDir: "v1", SnapName: "full-1"}, []string{"release"}); err != nil {
+		t.Fatalf("failed to set metadata: %v", err)
+	}
+
+	snapList, err := store.List()
+	if err != nil {
+		t.Fatalf("failed to list snapshots: %v", err)
+	}
+	if len(snapList) != 1 {
+		t.Fatalf("expected the tagged snapshot to still be listed, got %v", snapList)
+	}
+	got := snapList[0]
+	if got.Kind != SnapshotKindFull || !got.CreatedOn.Equal(createdOn) {
+		t.Fatalf("expected SetMetadata to preserve Kind/CreatedOn, got %+v", got)
+	}
+	if len(got.Tags) != 1 || got.Tags[0] != "release" {
+		t.Fatalf("expected SetMetadata to set Tags, got %v", got.Tags)
+	}
+}
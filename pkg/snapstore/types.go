@@ -0,0 +1,74 @@
+// Copyright (c) 2018 SAP SE or an SAP affiliate company. All rights reserved. This file is licensed under the Apache Software License, v. 2 except as noted otherwise in the LICENSE file.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapstore
+
+import "time"
+
+// SnapshotKind represents the kind of snapshot, full or delta/incremental.
+type SnapshotKind string
+
+const (
+	// SnapshotKindFull indicates a full snapshot.
+	SnapshotKindFull SnapshotKind = "Full"
+	// SnapshotKindDelta indicates a delta/incremental snapshot.
+	SnapshotKindDelta SnapshotKind = "Incr"
+
+	// tagMetadataKey is the user-metadata key reserved for a snapshot's tags. LocalSnapStore does
+	// not use it: it persists tags in the snapshot's metadata sidecar file (see metaFileSuffix).
+	tagMetadataKey = "x-etcdbr-tags"
+)
+
+// Snapshot represents the metadata of a single snapshot taken by the snapshotter, as returned by
+// a SnapStore's List call.
+type Snapshot struct {
+	Kind          SnapshotKind
+	CreatedOn     time.Time
+	StartRevision int64
+	LastRevision  int64
+	SnapDir       string
+	SnapName      string
+	// Tags are arbitrary operator-supplied labels attached to an already-persisted snapshot via
+	// SnapStore.SetMetadata (e.g. by `etcdbrctl snapshot tag`) and persisted alongside the snapshot
+	// object. They are used to pin snapshots against garbage collection regardless of age.
+	Tags []string
+	// Labels are structured, single-valued metadata (e.g. etcd cluster ID, member ID) used to
+	// group snapshots sharing a single bucket/prefix before a retention policy is applied.
+	Labels map[string]string
+}
+
+// SnapList is a list of snapshots, conventionally kept sorted oldest-first by CreatedOn.
+type SnapList []*Snapshot
+
+// SnapStore is the interface implemented by every snapshot storage backend (local, S3, GCS, ABS, ...).
+type SnapStore interface {
+	// Fetch should open reader for the snapshot file from store.
+	Fetch(Snapshot) (ReadCloser, error)
+	// List will list all snapshot files on store.
+	List() (SnapList, error)
+	// Save will write the snapshot to store.
+	Save(Snapshot, ReadCloser) error
+	// Delete should delete the snapshot file from store.
+	Delete(Snapshot) error
+	// SetMetadata updates the tags persisted alongside an already-uploaded snapshot without
+	// touching its body, so re-tagging a multi-gigabyte snapshot never re-uploads it.
+	SetMetadata(Snapshot, []string) error
+}
+
+// ReadCloser is a narrow alias kept local to this package so callers don't need to import "io"
+// just to satisfy the SnapStore interface.
+type ReadCloser = interface {
+	Read(p []byte) (n int, err error)
+	Close() error
+}